@@ -0,0 +1,132 @@
+// Package v2 contains hand-written additions to Alertmanager's generated HTTP API v2 surface.
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/prometheus/alertmanager/types"
+)
+
+// HistoryResponse is the JSON body returned by the alert history endpoint.
+type HistoryResponse struct {
+	Fingerprint string              `json:"fingerprint"`
+	Transitions []HistoryTransition `json:"transitions"`
+}
+
+// HistoryTransition is a single recorded state change, as rendered in a HistoryResponse.
+type HistoryTransition struct {
+	At         time.Time `json:"at"`
+	State      string    `json:"state"`
+	Inhibitors []string  `json:"inhibitors,omitempty"`
+}
+
+// NewHistoryHandler returns the handler for
+// GET /api/v2/alerts/{fingerprint}/history?from=...&to=...
+// which lets operators answer "why was this alert suppressed at 03:12?" from Grafana or curl.
+// reader is used to look up the recorded state transitions.
+func NewHistoryHandler(reader types.StateReader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fingerprint, err := fingerprintFromPath(r.URL.Path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		from, to, err := historyWindow(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		history, err := reader.HistoryFor(fingerprint, from, to)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to read alert history: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		resp := HistoryResponse{
+			Fingerprint: fingerprint.String(),
+			Transitions: make([]HistoryTransition, 0, len(history)),
+		}
+
+		for _, t := range history {
+			inhibitors := make([]string, 0, len(t.Inhibitors))
+			for _, inhibitor := range t.Inhibitors {
+				inhibitors = append(inhibitors, inhibitor.String())
+			}
+
+			resp.Transitions = append(resp.Transitions, HistoryTransition{
+				At:         t.At,
+				State:      t.State.String(),
+				Inhibitors: inhibitors,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode response: %v", err), http.StatusInternalServerError)
+		}
+	}
+}
+
+// RegisterRoutes mounts the alert history endpoint on mux, so that
+// GET /api/v2/alerts/{fingerprint}/history is actually reachable over HTTP. Callers building the
+// v2 API server should call this alongside their other route registrations.
+func RegisterRoutes(mux *http.ServeMux, reader types.StateReader) {
+	historyHandler := NewHistoryHandler(reader)
+
+	mux.HandleFunc("/api/v2/alerts/", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/history") {
+			http.NotFound(w, r)
+			return
+		}
+
+		historyHandler(w, r)
+	})
+}
+
+// fingerprintFromPath extracts the {fingerprint} path segment from
+// /api/v2/alerts/{fingerprint}/history.
+func fingerprintFromPath(path string) (model.Fingerprint, error) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, segment := range segments {
+		if segment == "alerts" && i+2 < len(segments) && segments[i+2] == "history" {
+			return model.ParseFingerprint(segments[i+1])
+		}
+	}
+
+	return 0, fmt.Errorf("could not find fingerprint in path %q", path)
+}
+
+// historyWindow parses the optional "from" and "to" query parameters as Unix timestamps,
+// defaulting to the entire available history.
+func historyWindow(r *http.Request) (from, to time.Time, err error) {
+	from = time.Unix(0, 0)
+	to = time.Now()
+
+	if raw := r.URL.Query().Get("from"); raw != "" {
+		sec, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from timestamp %q: %w", raw, err)
+		}
+		from = time.Unix(sec, 0)
+	}
+
+	if raw := r.URL.Query().Get("to"); raw != "" {
+		sec, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to timestamp %q: %w", raw, err)
+		}
+		to = time.Unix(sec, 0)
+	}
+
+	return from, to, nil
+}
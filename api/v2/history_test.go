@@ -0,0 +1,105 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/alertmanager/types"
+)
+
+type fakeStateReader struct {
+	history []types.StateTransition
+}
+
+func (f *fakeStateReader) HistoryFor(_ model.Fingerprint, _, _ time.Time) ([]types.StateTransition, error) {
+	return f.history, nil
+}
+
+func (f *fakeStateReader) ActiveAt(time.Time) (map[model.Fingerprint]types.AlertStatus, error) {
+	return nil, nil
+}
+
+func (f *fakeStateReader) InhibitorsOf(model.Fingerprint, time.Time) ([]model.Fingerprint, error) {
+	return nil, nil
+}
+
+func TestNewHistoryHandler(t *testing.T) {
+	now := time.Now()
+	reader := &fakeStateReader{
+		history: []types.StateTransition{
+			{At: now, State: types.AlertStateActive},
+			{At: now.Add(time.Minute), State: types.AlertStateSuppressed, Inhibitors: []model.Fingerprint{1}},
+		},
+	}
+
+	req := httptest.NewRequest("GET", "/api/v2/alerts/not-a-fingerprint/history", nil)
+	rr := httptest.NewRecorder()
+
+	NewHistoryHandler(reader).ServeHTTP(rr, req)
+
+	require.Equal(t, 400, rr.Code, "not-a-fingerprint is not a valid hex fingerprint")
+}
+
+func TestNewHistoryHandler_OK(t *testing.T) {
+	now := time.Now()
+	reader := &fakeStateReader{
+		history: []types.StateTransition{
+			{At: now, State: types.AlertStateActive},
+			{At: now.Add(time.Minute), State: types.AlertStateSuppressed, Inhibitors: []model.Fingerprint{1}},
+		},
+	}
+
+	req := httptest.NewRequest("GET", "/api/v2/alerts/0000000000000001/history", nil)
+	rr := httptest.NewRecorder()
+
+	NewHistoryHandler(reader).ServeHTTP(rr, req)
+
+	require.Equal(t, 200, rr.Code)
+
+	var resp HistoryResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+
+	require.Equal(t, model.Fingerprint(1).String(), resp.Fingerprint)
+	require.Len(t, resp.Transitions, 2)
+	require.Equal(t, "active", resp.Transitions[0].State)
+	require.Empty(t, resp.Transitions[0].Inhibitors)
+	require.Equal(t, "suppressed", resp.Transitions[1].State)
+	require.Equal(t, []string{model.Fingerprint(1).String()}, resp.Transitions[1].Inhibitors)
+}
+
+func TestRegisterRoutes(t *testing.T) {
+	now := time.Now()
+	reader := &fakeStateReader{
+		history: []types.StateTransition{
+			{At: now, State: types.AlertStateActive},
+		},
+	}
+
+	mux := http.NewServeMux()
+	RegisterRoutes(mux, reader)
+
+	req := httptest.NewRequest("GET", "/api/v2/alerts/0000000000000001/history", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	require.Equal(t, 200, rr.Code)
+
+	req = httptest.NewRequest("GET", "/api/v2/alerts/0000000000000001", nil)
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	require.Equal(t, 404, rr.Code, "only the /history suffix is registered")
+}
+
+func TestFingerprintFromPath(t *testing.T) {
+	fp, err := fingerprintFromPath("/api/v2/alerts/0000000000000001/history")
+	require.NoError(t, err)
+	require.Equal(t, model.Fingerprint(1), fp)
+
+	_, err = fingerprintFromPath("/api/v2/alerts/0000000000000001")
+	require.Error(t, err)
+}
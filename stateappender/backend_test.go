@@ -0,0 +1,43 @@
+package stateappender
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Open(t *testing.T) {
+	r := NewRegistry()
+	r.Register("memory", openMemoryBackend)
+
+	backend, err := r.Open(context.Background(), "memory://", nil)
+	require.NoError(t, err)
+	require.IsType(t, &MemoryBackend{}, backend)
+}
+
+func TestRegistry_Open_UnknownScheme(t *testing.T) {
+	r := NewRegistry()
+
+	_, err := r.Open(context.Background(), "unknown://", nil)
+	require.Error(t, err)
+}
+
+func TestMemoryBackend(t *testing.T) {
+	backend := NewMemoryBackend()
+	fp := model.Fingerprint(1)
+	now := time.Now()
+
+	require.NoError(t, backend.InsertActive(uuid.Must(uuid.NewV7()), fp, now))
+	require.NoError(t, backend.InsertInhibited(uuid.Must(uuid.NewV7()), fp, now, []string{"2"}))
+	require.NoError(t, backend.InsertDeleted(uuid.Must(uuid.NewV7()), fp, now))
+
+	rows := backend.Rows()
+	require.Len(t, rows, 3)
+	require.Equal(t, "active", rows[0].State)
+	require.Equal(t, []string{"2"}, rows[1].Inhibitors)
+	require.Equal(t, "deleted", rows[2].State)
+}
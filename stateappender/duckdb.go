@@ -0,0 +1,150 @@
+package stateappender
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcboeker/go-duckdb"
+	"github.com/prometheus/common/model"
+)
+
+func init() {
+	DefaultRegistry.Register("duckdb", openDuckDBBackend)
+}
+
+func openDuckDBBackend(ctx context.Context, rawURL string, l *slog.Logger) (Backend, error) {
+	dsn := strings.TrimPrefix(rawURL, "duckdb://")
+
+	db, err := sql.Open("duckdb", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open duckdb database: %w", err)
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire duckdb connection: %w", err)
+	}
+
+	var backend *DuckDBBackend
+	err = conn.Raw(func(driverConn any) error {
+		dc, ok := driverConn.(driver.Conn)
+		if !ok {
+			return fmt.Errorf("unexpected duckdb driver connection type %T", driverConn)
+		}
+
+		var err error
+		backend, err = NewDuckDBBackend(dc, l)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return backend, nil
+}
+
+// NewDuckDBBackend returns a Backend that appends alert state transitions to the
+// "alert_states" table, and their inhibitors to the many-to-many "alert_inhibitions" table,
+// over conn.
+func NewDuckDBBackend(conn driver.Conn, l *slog.Logger) (*DuckDBBackend, error) {
+	states, err := duckdb.NewAppenderFromConn(conn, "main", "alert_states")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create alert_states appender: %w", err)
+	}
+
+	inhibitions, err := duckdb.NewAppenderFromConn(conn, "main", "alert_inhibitions")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create alert_inhibitions appender: %w", err)
+	}
+
+	return &DuckDBBackend{db: states, inhibitions: inhibitions, l: l}, nil
+}
+
+// DuckDBBackend implements Backend on top of DuckDB's native Appender API.
+type DuckDBBackend struct {
+	db          *duckdb.Appender
+	inhibitions *duckdb.Appender
+	l           *slog.Logger
+}
+
+func (d *DuckDBBackend) Close() error {
+	if err := d.inhibitions.Close(); err != nil {
+		return fmt.Errorf("failed to close alert_inhibitions appender: %w", err)
+	}
+
+	return d.db.Close()
+}
+
+func (d *DuckDBBackend) Flush() error {
+	if err := d.inhibitions.Flush(); err != nil {
+		return fmt.Errorf("failed to flush alert_inhibitions appender: %w", err)
+	}
+
+	if err := d.db.Flush(); err != nil {
+		return fmt.Errorf("failed to flush duckdb state appender: %w", err)
+	}
+
+	return nil
+}
+
+func (d *DuckDBBackend) InsertActive(id uuid.UUID, fingerprint model.Fingerprint, at time.Time) error {
+	return d.appendRow(id, fingerprint, at, "active", nil)
+}
+
+func (d *DuckDBBackend) InsertSuppressed(id uuid.UUID, fingerprint model.Fingerprint, at time.Time) error {
+	return d.appendRow(id, fingerprint, at, "suppressed", "silenced")
+}
+
+func (d *DuckDBBackend) InsertInhibited(
+	id uuid.UUID,
+	fingerprint model.Fingerprint,
+	at time.Time,
+	inhibitors []string,
+) error {
+	if err := d.appendRow(id, fingerprint, at, "suppressed", "inhibited"); err != nil {
+		return err
+	}
+
+	idBinary, err := id.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("failed to marshal state id: %w", err)
+	}
+
+	for _, inhibitor := range inhibitors {
+		if err := d.inhibitions.AppendRow(idBinary, inhibitor); err != nil {
+			return fmt.Errorf("failed to append alert_inhibitions row: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (d *DuckDBBackend) InsertDeleted(id uuid.UUID, fingerprint model.Fingerprint, at time.Time) error {
+	return d.appendRow(id, fingerprint, at, "deleted", nil)
+}
+
+func (d *DuckDBBackend) appendRow(
+	id uuid.UUID,
+	fingerprint model.Fingerprint,
+	at time.Time,
+	state string,
+	reason any,
+) error {
+	idBinary, err := id.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("failed to marshal state id: %w", err)
+	}
+
+	if err := d.db.AppendRow(idBinary, at, fingerprint.String(), state, reason); err != nil {
+		return fmt.Errorf("failed to append alert_states row: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package stateappender
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/common/model"
+)
+
+func init() {
+	DefaultRegistry.Register("memory", openMemoryBackend)
+}
+
+func openMemoryBackend(_ context.Context, _ string, _ *slog.Logger) (Backend, error) {
+	return NewMemoryBackend(), nil
+}
+
+// Row is a single alert state transition, as recorded by MemoryBackend. It is exported so that
+// tests can assert on what was written.
+type Row struct {
+	ID          uuid.UUID
+	At          time.Time
+	Fingerprint model.Fingerprint
+	State       string
+	Inhibitors  []string
+	Reason      string
+}
+
+// MemoryBackend is an in-memory Backend, useful for tests and for running Alertmanager without
+// persisting alert state history across restarts.
+type MemoryBackend struct {
+	mu   sync.Mutex
+	rows []Row
+}
+
+// NewMemoryBackend returns an empty MemoryBackend.
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{}
+}
+
+// Rows returns a copy of all rows recorded so far.
+func (m *MemoryBackend) Rows() []Row {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Row(nil), m.rows...)
+}
+
+func (m *MemoryBackend) InsertActive(id uuid.UUID, fingerprint model.Fingerprint, at time.Time) error {
+	m.insert(Row{ID: id, At: at, Fingerprint: fingerprint, State: "active"})
+	return nil
+}
+
+func (m *MemoryBackend) InsertSuppressed(id uuid.UUID, fingerprint model.Fingerprint, at time.Time) error {
+	m.insert(Row{ID: id, At: at, Fingerprint: fingerprint, State: "suppressed", Reason: "silenced"})
+	return nil
+}
+
+func (m *MemoryBackend) InsertInhibited(
+	id uuid.UUID,
+	fingerprint model.Fingerprint,
+	at time.Time,
+	inhibitors []string,
+) error {
+	m.insert(Row{
+		ID:          id,
+		At:          at,
+		Fingerprint: fingerprint,
+		State:       "suppressed",
+		Inhibitors:  inhibitors,
+		Reason:      "inhibited",
+	})
+
+	return nil
+}
+
+func (m *MemoryBackend) InsertDeleted(id uuid.UUID, fingerprint model.Fingerprint, at time.Time) error {
+	m.insert(Row{ID: id, At: at, Fingerprint: fingerprint, State: "deleted"})
+	return nil
+}
+
+func (m *MemoryBackend) insert(row Row) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rows = append(m.rows, row)
+}
+
+func (m *MemoryBackend) Flush() error {
+	return nil
+}
+
+func (m *MemoryBackend) Close() error {
+	return nil
+}
@@ -0,0 +1,208 @@
+package stateappender
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/common/model"
+)
+
+func init() {
+	DefaultRegistry.Register("postgres", openPostgresBackend)
+}
+
+// defaultMaxBufferedRows bounds how many alert_states rows PostgresBackend holds in memory
+// before it auto-flushes. Without this bound, a caller that never flushes on its own (or one
+// wrapped around RetryingStateAppender, which only retries writes that already failed) would
+// let the buffer grow without limit.
+const defaultMaxBufferedRows = 10000
+
+func openPostgresBackend(ctx context.Context, rawURL string, l *slog.Logger) (Backend, error) {
+	pool, err := pgxpool.New(ctx, rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres connection pool: %w", err)
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to connect to postgres database: %w", err)
+	}
+
+	return &PostgresBackend{pool: pool, l: l, maxBufferedRows: defaultMaxBufferedRows}, nil
+}
+
+// postgresRow is a single row buffered in memory until the next Flush, at which point it is
+// written out via a COPY rather than one round-trip per row.
+type postgresRow struct {
+	id          uuid.UUID
+	at          time.Time
+	fingerprint string
+	state       string
+	reason      any
+}
+
+// postgresInhibitorRow is a single alert_inhibitions row buffered alongside its parent
+// postgresRow.
+type postgresInhibitorRow struct {
+	stateID   uuid.UUID
+	inhibitor string
+}
+
+// PostgresBackend implements Backend on top of pgx. Rows are buffered in memory and written out
+// in bulk via COPY on Flush, rather than issuing one round-trip per row.
+//
+// A row is only durable once Flush (or Close) succeeds: the Insert* methods merely buffer it in
+// memory, so a crash before the next flush loses whatever is currently buffered. maxBufferedRows
+// bounds how large that window can grow, auto-flushing once it is reached; callers that need a
+// tighter durability guarantee should flush on a shorter interval themselves (e.g. by wrapping
+// this backend the way RetryingStateAppender's caller is expected to schedule periodic flushes).
+type PostgresBackend struct {
+	pool *pgxpool.Pool
+	l    *slog.Logger
+
+	// maxBufferedRows bounds how many rows are held in memory before an Insert call triggers an
+	// automatic Flush. Zero means no bound.
+	maxBufferedRows int
+
+	mu         sync.Mutex
+	rows       []postgresRow
+	inhibitors []postgresInhibitorRow
+}
+
+func (p *PostgresBackend) InsertActive(id uuid.UUID, fingerprint model.Fingerprint, at time.Time) error {
+	return p.buffer(id, fingerprint, at, "active", nil)
+}
+
+func (p *PostgresBackend) InsertSuppressed(id uuid.UUID, fingerprint model.Fingerprint, at time.Time) error {
+	return p.buffer(id, fingerprint, at, "suppressed", "silenced")
+}
+
+func (p *PostgresBackend) InsertInhibited(
+	id uuid.UUID,
+	fingerprint model.Fingerprint,
+	at time.Time,
+	inhibitors []string,
+) error {
+	p.mu.Lock()
+
+	p.rows = append(p.rows, postgresRow{
+		id:          id,
+		at:          at,
+		fingerprint: fingerprint.String(),
+		state:       "suppressed",
+		reason:      "inhibited",
+	})
+
+	for _, inhibitor := range inhibitors {
+		p.inhibitors = append(p.inhibitors, postgresInhibitorRow{stateID: id, inhibitor: inhibitor})
+	}
+
+	full := p.bufferFullLocked()
+	p.mu.Unlock()
+
+	if full {
+		return p.Flush()
+	}
+
+	return nil
+}
+
+func (p *PostgresBackend) InsertDeleted(id uuid.UUID, fingerprint model.Fingerprint, at time.Time) error {
+	return p.buffer(id, fingerprint, at, "deleted", nil)
+}
+
+// buffer appends a row to the in-memory buffer, auto-flushing (and surfacing any resulting
+// error) once maxBufferedRows is reached.
+func (p *PostgresBackend) buffer(
+	id uuid.UUID,
+	fingerprint model.Fingerprint,
+	at time.Time,
+	state string,
+	reason any,
+) error {
+	p.mu.Lock()
+	p.rows = append(p.rows, postgresRow{
+		id:          id,
+		at:          at,
+		fingerprint: fingerprint.String(),
+		state:       state,
+		reason:      reason,
+	})
+	full := p.bufferFullLocked()
+	p.mu.Unlock()
+
+	if full {
+		return p.Flush()
+	}
+
+	return nil
+}
+
+// bufferFullLocked reports whether the buffer has reached maxBufferedRows. p.mu must be held.
+func (p *PostgresBackend) bufferFullLocked() bool {
+	return p.maxBufferedRows > 0 && len(p.rows) >= p.maxBufferedRows
+}
+
+func (p *PostgresBackend) Flush() error {
+	p.mu.Lock()
+	rows := p.rows
+	inhibitors := p.inhibitors
+	p.rows = nil
+	p.inhibitors = nil
+	p.mu.Unlock()
+
+	ctx := context.Background()
+
+	if len(rows) > 0 {
+		source := pgx.CopyFromSlice(len(rows), func(i int) ([]any, error) {
+			r := rows[i]
+			return []any{r.id, r.at, r.fingerprint, r.state, r.reason}, nil
+		})
+
+		_, err := p.pool.CopyFrom(
+			ctx,
+			pgx.Identifier{"alert_states"},
+			[]string{"id", "ts", "fingerprint", "state", "reason"},
+			source,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to copy alert state rows into postgres: %w", err)
+		}
+	}
+
+	if len(inhibitors) > 0 {
+		source := pgx.CopyFromSlice(len(inhibitors), func(i int) ([]any, error) {
+			r := inhibitors[i]
+			return []any{r.stateID, r.inhibitor}, nil
+		})
+
+		_, err := p.pool.CopyFrom(
+			ctx,
+			pgx.Identifier{"alert_inhibitions"},
+			[]string{"state_id", "inhibitor_fingerprint"},
+			source,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to copy alert_inhibitions rows into postgres: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (p *PostgresBackend) Close() error {
+	if err := p.Flush(); err != nil {
+		p.pool.Close()
+		return err
+	}
+
+	p.pool.Close()
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package stateappender
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/common/model"
+
+	_ "modernc.org/sqlite"
+)
+
+func init() {
+	DefaultRegistry.Register("sqlite", openSQLiteBackend)
+}
+
+func openSQLiteBackend(ctx context.Context, rawURL string, l *slog.Logger) (Backend, error) {
+	dsn := strings.TrimPrefix(rawURL, "sqlite://")
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to sqlite database: %w", err)
+	}
+
+	insert, err := db.PrepareContext(ctx, `
+		INSERT INTO alert_states (id, ts, fingerprint, state, reason)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare sqlite insert statement: %w", err)
+	}
+
+	insertInhibitor, err := db.PrepareContext(ctx, `
+		INSERT INTO alert_inhibitions (state_id, inhibitor_fingerprint)
+		VALUES (?, ?)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare sqlite inhibitor insert statement: %w", err)
+	}
+
+	return &SQLiteBackend{db: db, insert: insert, insertInhibitor: insertInhibitor, l: l}, nil
+}
+
+// SQLiteBackend implements Backend on top of modernc.org/sqlite using prepared statements,
+// which that driver can reuse across calls without re-parsing the query every time.
+type SQLiteBackend struct {
+	db              *sql.DB
+	insert          *sql.Stmt
+	insertInhibitor *sql.Stmt
+	l               *slog.Logger
+
+	mu sync.Mutex
+}
+
+func (s *SQLiteBackend) InsertActive(id uuid.UUID, fingerprint model.Fingerprint, at time.Time) error {
+	return s.exec(id, fingerprint, at, "active", nil)
+}
+
+func (s *SQLiteBackend) InsertSuppressed(id uuid.UUID, fingerprint model.Fingerprint, at time.Time) error {
+	return s.exec(id, fingerprint, at, "suppressed", "silenced")
+}
+
+func (s *SQLiteBackend) InsertInhibited(
+	id uuid.UUID,
+	fingerprint model.Fingerprint,
+	at time.Time,
+	inhibitors []string,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin sqlite transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if _, err := tx.Stmt(s.insert).Exec(id[:], at, fingerprint.String(), "suppressed", "inhibited"); err != nil {
+		return fmt.Errorf("failed to insert alert state row: %w", err)
+	}
+
+	for _, inhibitor := range inhibitors {
+		if _, err := tx.Stmt(s.insertInhibitor).Exec(id[:], inhibitor); err != nil {
+			return fmt.Errorf("failed to insert alert_inhibitions row: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit sqlite transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteBackend) InsertDeleted(id uuid.UUID, fingerprint model.Fingerprint, at time.Time) error {
+	return s.exec(id, fingerprint, at, "deleted", nil)
+}
+
+func (s *SQLiteBackend) exec(
+	id uuid.UUID,
+	fingerprint model.Fingerprint,
+	at time.Time,
+	state string,
+	reason any,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.insert.Exec(id[:], at, fingerprint.String(), state, reason); err != nil {
+		return fmt.Errorf("failed to insert alert state row: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteBackend) Flush() error {
+	// Inserts are executed synchronously against the database, so there is nothing to flush.
+	return nil
+}
+
+func (s *SQLiteBackend) Close() error {
+	if err := s.insertInhibitor.Close(); err != nil {
+		return fmt.Errorf("failed to close sqlite inhibitor insert statement: %w", err)
+	}
+
+	if err := s.insert.Close(); err != nil {
+		return fmt.Errorf("failed to close sqlite insert statement: %w", err)
+	}
+
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("failed to close sqlite database: %w", err)
+	}
+
+	return nil
+}
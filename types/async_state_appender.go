@@ -0,0 +1,310 @@
+package types
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+// AsyncStateAppenderConfig configures an AsyncStateAppender.
+type AsyncStateAppenderConfig struct {
+	// QueueCapacity is the maximum number of pending append requests held in the queue before
+	// the oldest queued ones start getting dropped to make room for new ones. Requests the
+	// background worker has already taken into its current batch (up to BatchSize) are not
+	// counted against it and are never dropped.
+	QueueCapacity int
+	// BatchSize is the number of queued requests the background worker will accumulate
+	// before flushing them to the wrapped StateAppender.
+	BatchSize int
+	// FlushInterval is the maximum amount of time a batch is allowed to sit in memory
+	// before it is flushed to the wrapped StateAppender, regardless of BatchSize.
+	FlushInterval time.Duration
+}
+
+// DefaultAsyncStateAppenderConfig returns sane defaults for AsyncStateAppenderConfig.
+func DefaultAsyncStateAppenderConfig() AsyncStateAppenderConfig {
+	return AsyncStateAppenderConfig{
+		QueueCapacity: 4096,
+		BatchSize:     128,
+		FlushInterval: 5 * time.Second,
+	}
+}
+
+// appendRequest is a single queued call to the wrapped StateAppender. Exactly one of the
+// two sets of fields is populated, mirroring the two StateAppender methods.
+type appendRequest struct {
+	fingerprint model.Fingerprint
+	state       AlertState
+	inhibited   bool
+	inhibitedBy []string
+}
+
+// asyncStateAppenderMetrics holds the prometheus instrumentation exposed by AsyncStateAppender.
+type asyncStateAppenderMetrics struct {
+	droppedTotal      prometheus.Counter
+	appendErrorsTotal prometheus.Counter
+	queueLength       prometheus.GaugeFunc
+	queueCapacity     prometheus.Gauge
+	flushDuration     prometheus.Histogram
+}
+
+func newAsyncStateAppenderMetrics(
+	r prometheus.Registerer,
+	queueLen func() float64,
+	capacity int,
+) *asyncStateAppenderMetrics {
+	m := &asyncStateAppenderMetrics{
+		droppedTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "alertmanager",
+			Subsystem: "state_appender",
+			Name:      "dropped_total",
+			Help:      "Total number of state records dropped because the async queue was full.",
+		}),
+		appendErrorsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "alertmanager",
+			Subsystem: "state_appender",
+			Name:      "append_errors_total",
+			Help:      "Total number of errors encountered while appending a state record asynchronously.",
+		}),
+		queueCapacity: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "alertmanager",
+			Subsystem: "state_appender",
+			Name:      "queue_capacity",
+			Help:      "Capacity of the async state appender queue.",
+		}),
+		flushDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "alertmanager",
+			Subsystem: "state_appender",
+			Name:      "flush_duration_seconds",
+			Help:      "Time it took to flush the async state appender queue to the underlying appender.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+	}
+	m.queueLength = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "alertmanager",
+		Subsystem: "state_appender",
+		Name:      "queue_length",
+		Help:      "Number of state records currently buffered in the async state appender queue.",
+	}, queueLen)
+	m.queueCapacity.Set(float64(capacity))
+
+	if r != nil {
+		r.MustRegister(
+			m.droppedTotal,
+			m.appendErrorsTotal,
+			m.queueLength,
+			m.queueCapacity,
+			m.flushDuration,
+		)
+	}
+
+	return m
+}
+
+// AsyncStateAppender wraps a StateAppender and enqueues Append/AppendInhibited calls onto a
+// bounded, in-memory queue that is drained by a background goroutine. This keeps callers such
+// as StateAwareMarker off the notification/inhibition hot path, since they no longer block on
+// the underlying storage system (e.g. a DuckDB write).
+//
+// When the queue is full, the oldest queued record is dropped to make room for the new one, so
+// that recent state always wins over stale state that never got persisted.
+//
+// The background goroutine started by NewAsyncStateAppender is the sole owner of both the queue
+// and the in-flight batch: Flush and Close never touch either directly, they hand a request to
+// that goroutine and wait for it to reply. This avoids a race where Flush() could observe the
+// queue as empty while the goroutine was still holding records it had already dequeued but not
+// yet applied.
+type AsyncStateAppender struct {
+	next StateAppender
+	cfg  AsyncStateAppenderConfig
+
+	queue         chan appendRequest
+	flushRequests chan chan error
+	metrics       *asyncStateAppenderMetrics
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	done      chan struct{}
+}
+
+// NewAsyncStateAppender returns an AsyncStateAppender that wraps next and starts its background
+// drain loop. Callers must call Close to release the background goroutine.
+func NewAsyncStateAppender(
+	r prometheus.Registerer,
+	next StateAppender,
+	cfg AsyncStateAppenderConfig,
+) *AsyncStateAppender {
+	queue := make(chan appendRequest, cfg.QueueCapacity)
+
+	a := &AsyncStateAppender{
+		next:          next,
+		cfg:           cfg,
+		queue:         queue,
+		flushRequests: make(chan chan error),
+		closed:        make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	a.metrics = newAsyncStateAppenderMetrics(r, func() float64 { return float64(len(queue)) }, cfg.QueueCapacity)
+
+	go a.run()
+
+	return a
+}
+
+func (a *AsyncStateAppender) Append(fingerprint model.Fingerprint, state AlertState) {
+	a.enqueue(appendRequest{fingerprint: fingerprint, state: state})
+}
+
+func (a *AsyncStateAppender) AppendInhibited(fingerprint model.Fingerprint, inhibitedBy []string) {
+	a.enqueue(appendRequest{fingerprint: fingerprint, inhibited: true, inhibitedBy: inhibitedBy})
+}
+
+// enqueue pushes req onto the queue, dropping the oldest queued request if the queue is full.
+func (a *AsyncStateAppender) enqueue(req appendRequest) {
+	select {
+	case a.queue <- req:
+		return
+	default:
+	}
+
+	// The queue is full: drop the oldest record to make room for this one.
+	select {
+	case <-a.queue:
+		a.metrics.droppedTotal.Inc()
+	default:
+	}
+
+	select {
+	case a.queue <- req:
+	default:
+		// Another goroutine raced us and refilled the queue; drop this record instead.
+		a.metrics.droppedTotal.Inc()
+	}
+}
+
+// run is the single owner of the queue and the pending batch. It drains the queue in batches of
+// cfg.BatchSize, flushing to the wrapped appender early if cfg.FlushInterval elapses before a
+// batch fills up, and services Flush/Close requests in between.
+func (a *AsyncStateAppender) run() {
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]appendRequest, 0, a.cfg.BatchSize)
+
+	applyBatch := func() {
+		if len(batch) == 0 {
+			return
+		}
+		a.applyAll(batch)
+		batch = batch[:0]
+	}
+
+	// flushToNext applies whatever is batched and flushes the wrapped appender, so that both
+	// the size-triggered and time-triggered paths actually make the batch durable instead of
+	// just handing it to the (possibly still-buffering) wrapped appender.
+	flushToNext := func() error {
+		start := time.Now()
+		defer func() { a.metrics.flushDuration.Observe(time.Since(start).Seconds()) }()
+
+		applyBatch()
+
+		return a.next.Flush()
+	}
+
+	drainQueue := func() {
+		for {
+			select {
+			case req := <-a.queue:
+				batch = append(batch, req)
+			default:
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case req, ok := <-a.queue:
+			if !ok {
+				applyBatch()
+				return
+			}
+
+			batch = append(batch, req)
+			if len(batch) >= a.cfg.BatchSize {
+				if err := flushToNext(); err != nil {
+					a.metrics.appendErrorsTotal.Inc()
+				}
+			}
+		case <-ticker.C:
+			if err := flushToNext(); err != nil {
+				a.metrics.appendErrorsTotal.Inc()
+			}
+		case respCh := <-a.flushRequests:
+			// Drain anything sitting in the queue before flushing, so Flush() is guaranteed
+			// to see every record enqueued before it was called.
+			drainQueue()
+			respCh <- flushToNext()
+		case <-a.closed:
+			drainQueue()
+			applyBatch()
+			return
+		}
+	}
+}
+
+func (a *AsyncStateAppender) applyAll(batch []appendRequest) {
+	for _, req := range batch {
+		a.apply(req)
+	}
+}
+
+func (a *AsyncStateAppender) apply(req appendRequest) {
+	defer func() {
+		if r := recover(); r != nil {
+			a.metrics.appendErrorsTotal.Inc()
+		}
+	}()
+
+	if req.inhibited {
+		a.next.AppendInhibited(req.fingerprint, req.inhibitedBy)
+		return
+	}
+
+	a.next.Append(req.fingerprint, req.state)
+}
+
+// Flush synchronously drains the queue and then flushes the wrapped StateAppender. It hands the
+// work to the background goroutine and waits for it, rather than draining the queue itself, so
+// it never races with that goroutine's in-flight batch.
+func (a *AsyncStateAppender) Flush() error {
+	respCh := make(chan error, 1)
+
+	select {
+	case a.flushRequests <- respCh:
+	case <-a.done:
+		// The background goroutine has already exited (e.g. after Close); there is nothing
+		// left for it to drain on our behalf.
+		return a.next.Flush()
+	}
+
+	return <-respCh
+}
+
+// Close drains the queue, stops the background worker, and closes the wrapped StateAppender. It
+// is safe to call Close more than once.
+func (a *AsyncStateAppender) Close() error {
+	var err error
+
+	a.closeOnce.Do(func() {
+		close(a.closed)
+		<-a.done
+		err = a.next.Close()
+	})
+
+	return err
+}
@@ -0,0 +1,112 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAsyncStateAppender_DropsOldestWhenQueueFull(t *testing.T) {
+	mock := gomock.NewController(t)
+	next := NewMockStateAppender(mock)
+
+	// The background goroutine takes fingerprint 1 off the queue and then blocks inside next, so
+	// nothing drains the queue (capacity 1) while 2 and 3 are enqueued: 3 displaces 2, the
+	// oldest queued record, and 2 never reaches next.
+	started := make(chan struct{})
+	release := make(chan struct{})
+	next.EXPECT().Append(model.Fingerprint(1), AlertStateActive).Do(func(model.Fingerprint, AlertState) {
+		close(started)
+		<-release
+	})
+	next.EXPECT().Append(model.Fingerprint(3), AlertStateActive)
+	next.EXPECT().Flush().Return(nil).AnyTimes()
+	next.EXPECT().Close().Return(nil)
+
+	a := NewAsyncStateAppender(prometheus.NewRegistry(), next, AsyncStateAppenderConfig{
+		QueueCapacity: 1,
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+	})
+	defer a.Close()
+
+	a.Append(model.Fingerprint(1), AlertStateActive)
+	<-started
+	a.Append(model.Fingerprint(2), AlertStateActive)
+	a.Append(model.Fingerprint(3), AlertStateActive)
+	close(release)
+
+	require.NoError(t, a.Flush())
+}
+
+func TestAsyncStateAppender_FlushAppliesEverythingEnqueuedBeforeIt(t *testing.T) {
+	mock := gomock.NewController(t)
+	next := NewMockStateAppender(mock)
+
+	next.EXPECT().Append(model.Fingerprint(1), AlertStateActive)
+	next.EXPECT().Append(model.Fingerprint(2), AlertStateSuppressed)
+	next.EXPECT().Flush().Return(nil)
+	next.EXPECT().Close().Return(nil)
+
+	a := NewAsyncStateAppender(prometheus.NewRegistry(), next, AsyncStateAppenderConfig{
+		QueueCapacity: 10,
+		BatchSize:     10,
+		FlushInterval: time.Hour,
+	})
+	defer a.Close()
+
+	a.Append(model.Fingerprint(1), AlertStateActive)
+	a.Append(model.Fingerprint(2), AlertStateSuppressed)
+
+	require.NoError(t, a.Flush())
+}
+
+func TestAsyncStateAppender_FlushesOnBatchSize(t *testing.T) {
+	mock := gomock.NewController(t)
+	next := NewMockStateAppender(mock)
+
+	done := make(chan struct{})
+	next.EXPECT().Append(model.Fingerprint(1), AlertStateActive)
+	next.EXPECT().Flush().DoAndReturn(func() error {
+		close(done)
+		return nil
+	})
+	next.EXPECT().Close().Return(nil)
+
+	a := NewAsyncStateAppender(prometheus.NewRegistry(), next, AsyncStateAppenderConfig{
+		QueueCapacity: 10,
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+	})
+	defer a.Close()
+
+	a.Append(model.Fingerprint(1), AlertStateActive)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("batch was never flushed to the wrapped appender")
+	}
+}
+
+func TestAsyncStateAppender_Close(t *testing.T) {
+	mock := gomock.NewController(t)
+	next := NewMockStateAppender(mock)
+
+	next.EXPECT().Append(model.Fingerprint(1), AlertStateActive)
+	next.EXPECT().Close().Return(nil)
+
+	a := NewAsyncStateAppender(prometheus.NewRegistry(), next, AsyncStateAppenderConfig{
+		QueueCapacity: 10,
+		BatchSize:     10,
+		FlushInterval: time.Hour,
+	})
+
+	a.Append(model.Fingerprint(1), AlertStateActive)
+	require.NoError(t, a.Close())
+	require.NoError(t, a.Close())
+}
@@ -0,0 +1,331 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+
+	"github.com/prometheus/alertmanager/cluster"
+	"github.com/prometheus/alertmanager/stateappender"
+)
+
+// clusterEvent is the wire format broadcast to peers and gossiped between them. It carries
+// enough information for a receiver to idempotently replay the transition into its own backend:
+// the UUIDv7 id, fingerprint, timestamp, state, and inhibitors.
+type clusterEvent struct {
+	ID          uuid.UUID         `json:"id"`
+	Fingerprint model.Fingerprint `json:"fingerprint"`
+	At          time.Time         `json:"at"`
+	State       AlertState        `json:"state"`
+	Inhibitors  []string          `json:"inhibitors,omitempty"`
+}
+
+func (ev clusterEvent) write(backend stateappender.Backend) error {
+	if len(ev.Inhibitors) > 0 {
+		return backend.InsertInhibited(ev.ID, ev.Fingerprint, ev.At, ev.Inhibitors)
+	}
+
+	return insertState(backend, ev.ID, ev.Fingerprint, ev.State, ev.At)
+}
+
+type clusterStateAppenderMetrics struct {
+	replicatedTotal prometheus.Counter
+	conflictsTotal  prometheus.Counter
+	replicationLag  prometheus.Gauge
+}
+
+func newClusterStateAppenderMetrics(r prometheus.Registerer) *clusterStateAppenderMetrics {
+	m := &clusterStateAppenderMetrics{
+		replicatedTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "alertmanager",
+			Subsystem: "cluster_state",
+			Name:      "replicated_total",
+			Help:      "Total number of alert state events this node has broadcast to its peers.",
+		}),
+		conflictsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "alertmanager",
+			Subsystem: "cluster_state",
+			Name:      "conflicts_total",
+			Help:      "Total number of alert state events received more than once, e.g. after a partition heals.",
+		}),
+		replicationLag: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "alertmanager",
+			Subsystem: "cluster_state",
+			Name:      "replication_lag_seconds",
+			Help:      "Time between a peer recording an alert state event and this node applying it.",
+		}),
+	}
+
+	if r != nil {
+		r.MustRegister(m.replicatedTotal, m.conflictsTotal, m.replicationLag)
+	}
+
+	return m
+}
+
+// dedupeSeenTTL bounds how long ClusterStateAppender remembers an event id for the purposes of
+// Merge deduplication. It only needs to cover how long a gossip message can plausibly be
+// redelivered for (e.g. while a partition heals), not the lifetime of the process.
+const dedupeSeenTTL = 30 * time.Minute
+
+// dedupeSet tracks recently-seen event ids, evicting entries older than ttl so memory use stays
+// bounded regardless of how long the process runs.
+type dedupeSet struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[uuid.UUID]time.Time
+}
+
+func newDedupeSet(ttl time.Duration) *dedupeSet {
+	return &dedupeSet{ttl: ttl, entries: make(map[uuid.UUID]time.Time)}
+}
+
+// markSeen records id as applied and reports whether it was new.
+func (s *dedupeSet) markSeen(id uuid.UUID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[id]; ok {
+		return false
+	}
+
+	s.entries[id] = time.Now()
+
+	return true
+}
+
+// evictExpired removes every entry older than s.ttl.
+func (s *dedupeSet) evictExpired() {
+	cutoff := time.Now().Add(-s.ttl)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, seenAt := range s.entries {
+		if seenAt.Before(cutoff) {
+			delete(s.entries, id)
+		}
+	}
+}
+
+// ClusterHistorySource is a StateReader that can also enumerate every transition since a point
+// in time, regardless of fingerprint. ClusterStateAppender.Sync uses it to pull a peer's recent
+// history at startup.
+type ClusterHistorySource interface {
+	StateReader
+	// AllHistorySince returns every transition recorded at or after since, across all
+	// fingerprints, ordered oldest first.
+	AllHistorySince(since time.Time) ([]StateTransition, error)
+}
+
+// ClusterStateAppender wraps a stateappender.Backend and fans out every Append/AppendInhibited
+// call to peer Alertmanagers over the cluster package's gossip transport (the same one
+// silence.Silences and nflog.Log use), so alert-state history survives the loss of a single node
+// instead of living only on whichever instance happened to process the alert.
+//
+// It operates on a Backend rather than a StateAppender so that a gossiped or synced event's
+// original id and timestamp survive all the way to storage: replaying through StateAppender.
+// Append(fingerprint, state) would mint a fresh id and use time.Now(), breaking both the
+// id-primary-key idempotency receivers rely on and the accuracy of synced history.
+//
+// It implements cluster.State so it can be registered with ClusterPeer.AddState: Merge is
+// invoked by the cluster layer for every event a peer broadcasts. MarshalBinary encodes nothing,
+// so the cluster layer's full-state push carries no events; a newly-joined node catches up via
+// Sync instead. Receivers insert on the event's id,
+// which is its primary key in the underlying storage, so re-delivering the same event after a
+// partition heals is always safe.
+type ClusterStateAppender struct {
+	backend stateappender.Backend
+	channel *cluster.Channel
+	l       *slog.Logger
+	metrics *clusterStateAppenderMetrics
+
+	seen *dedupeSet
+
+	closeOnce sync.Once
+	stopEvict chan struct{}
+	evictDone chan struct{}
+}
+
+// ClusterPeer is the subset of *cluster.Peer that ClusterStateAppender needs. It is declared
+// locally, the same way nflog and silence each declare their own narrow peer interface, rather
+// than depending on a wider concrete type from the cluster package.
+type ClusterPeer interface {
+	AddState(key string, state cluster.State, reg prometheus.Registerer) *cluster.Channel
+}
+
+// NewClusterStateAppender wraps backend, registers it with peer under key, and returns it ready
+// to broadcast local events and receive gossiped ones.
+func NewClusterStateAppender(
+	r prometheus.Registerer,
+	peer ClusterPeer,
+	key string,
+	backend stateappender.Backend,
+	l *slog.Logger,
+) *ClusterStateAppender {
+	a := &ClusterStateAppender{
+		backend:   backend,
+		l:         l,
+		metrics:   newClusterStateAppenderMetrics(r),
+		seen:      newDedupeSet(dedupeSeenTTL),
+		stopEvict: make(chan struct{}),
+		evictDone: make(chan struct{}),
+	}
+
+	a.channel = peer.AddState(key, a, r)
+
+	go a.runEviction()
+
+	return a
+}
+
+func (a *ClusterStateAppender) runEviction() {
+	defer close(a.evictDone)
+
+	ticker := time.NewTicker(a.seen.ttl)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			a.seen.evictExpired()
+		case <-a.stopEvict:
+			return
+		}
+	}
+}
+
+func (a *ClusterStateAppender) Append(fingerprint model.Fingerprint, state AlertState) {
+	ev := clusterEvent{
+		ID:          uuid.Must(uuid.NewV7()),
+		Fingerprint: fingerprint,
+		At:          time.Now(),
+		State:       state,
+	}
+
+	if err := ev.write(a.backend); err != nil {
+		a.l.Error("failed to append alert state", slog.Any("error", err))
+	}
+
+	a.broadcast(ev)
+}
+
+func (a *ClusterStateAppender) AppendInhibited(fingerprint model.Fingerprint, inhibitedBy []string) {
+	ev := clusterEvent{
+		ID:          uuid.Must(uuid.NewV7()),
+		Fingerprint: fingerprint,
+		At:          time.Now(),
+		State:       AlertStateSuppressed,
+		Inhibitors:  inhibitedBy,
+	}
+
+	if err := ev.write(a.backend); err != nil {
+		a.l.Error("failed to append inhibited alert state", slog.Any("error", err))
+	}
+
+	a.broadcast(ev)
+}
+
+func (a *ClusterStateAppender) broadcast(ev clusterEvent) {
+	// Our own event can never be a conflict, but recording it in `seen` means we correctly
+	// ignore it if it's ever delivered back to us.
+	a.seen.markSeen(ev.ID)
+
+	b, err := json.Marshal(ev)
+	if err != nil {
+		a.l.Error("failed to marshal cluster state event", slog.Any("error", err))
+		return
+	}
+
+	a.channel.Broadcast(b)
+	a.metrics.replicatedTotal.Inc()
+}
+
+// MarshalBinary implements cluster.State. ClusterStateAppender keeps no in-memory state to
+// snapshot: a newly-joined peer catches up via Sync pulling ClusterHistorySource.AllHistorySince,
+// not via the cluster layer's full-state push, so there is nothing to encode here.
+func (a *ClusterStateAppender) MarshalBinary() ([]byte, error) {
+	return nil, nil
+}
+
+// Merge implements cluster.State.
+func (a *ClusterStateAppender) Merge(b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+
+	var ev clusterEvent
+	if err := json.Unmarshal(b, &ev); err != nil {
+		return fmt.Errorf("failed to unmarshal cluster state event: %w", err)
+	}
+
+	a.apply(ev)
+
+	return nil
+}
+
+func (a *ClusterStateAppender) apply(ev clusterEvent) {
+	if !a.seen.markSeen(ev.ID) {
+		a.metrics.conflictsTotal.Inc()
+		return
+	}
+
+	a.metrics.replicationLag.Set(time.Since(ev.At).Seconds())
+
+	if err := ev.write(a.backend); err != nil {
+		a.l.Error("failed to apply gossiped alert state event", slog.Any("error", err))
+	}
+}
+
+// Ready implements cluster.State. ClusterStateAppender has no settling phase of its own: it is
+// always ready to accept gossiped events, even before Sync has completed.
+func (a *ClusterStateAppender) Ready() bool {
+	return true
+}
+
+// Sync pulls every transition peer has recorded in the last `since` and replays it locally, so a
+// freshly-joined replica catches up on recent history instead of starting from empty and
+// diverging from the rest of the cluster.
+func (a *ClusterStateAppender) Sync(peer ClusterHistorySource, since time.Duration) error {
+	transitions, err := peer.AllHistorySince(time.Now().Add(-since))
+	if err != nil {
+		return fmt.Errorf("failed to sync cluster state from peer: %w", err)
+	}
+
+	for _, t := range transitions {
+		inhibitors := make([]string, 0, len(t.Inhibitors))
+		for _, inhibitor := range t.Inhibitors {
+			inhibitors = append(inhibitors, inhibitor.String())
+		}
+
+		a.apply(clusterEvent{
+			ID:          t.ID,
+			Fingerprint: t.Fingerprint,
+			At:          t.At,
+			State:       t.State,
+			Inhibitors:  inhibitors,
+		})
+	}
+
+	return nil
+}
+
+func (a *ClusterStateAppender) Flush() error {
+	return a.backend.Flush()
+}
+
+func (a *ClusterStateAppender) Close() error {
+	a.closeOnce.Do(func() {
+		close(a.stopEvict)
+		<-a.evictDone
+	})
+
+	return a.backend.Close()
+}
@@ -0,0 +1,141 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingBackend implements stateappender.Backend and records the id/at/state passed to each
+// insert, so tests can assert that ClusterStateAppender preserves a gossiped event's original id
+// and timestamp instead of minting new ones.
+type recordingBackend struct {
+	ids   []uuid.UUID
+	ats   []time.Time
+	state []string
+}
+
+func (b *recordingBackend) InsertActive(id uuid.UUID, _ model.Fingerprint, at time.Time) error {
+	b.ids = append(b.ids, id)
+	b.ats = append(b.ats, at)
+	b.state = append(b.state, "active")
+	return nil
+}
+
+func (b *recordingBackend) InsertSuppressed(id uuid.UUID, _ model.Fingerprint, at time.Time) error {
+	b.ids = append(b.ids, id)
+	b.ats = append(b.ats, at)
+	b.state = append(b.state, "suppressed")
+	return nil
+}
+
+func (b *recordingBackend) InsertInhibited(id uuid.UUID, _ model.Fingerprint, at time.Time, _ []string) error {
+	b.ids = append(b.ids, id)
+	b.ats = append(b.ats, at)
+	b.state = append(b.state, "suppressed")
+	return nil
+}
+
+func (b *recordingBackend) InsertDeleted(id uuid.UUID, _ model.Fingerprint, at time.Time) error {
+	b.ids = append(b.ids, id)
+	b.ats = append(b.ats, at)
+	b.state = append(b.state, "deleted")
+	return nil
+}
+
+func (b *recordingBackend) Flush() error { return nil }
+
+func (b *recordingBackend) Close() error { return nil }
+
+func newTestClusterStateAppender(backend *recordingBackend) *ClusterStateAppender {
+	return &ClusterStateAppender{
+		backend:   backend,
+		l:         discardLogger(),
+		metrics:   newClusterStateAppenderMetrics(prometheus.NewRegistry()),
+		seen:      newDedupeSet(time.Hour),
+		stopEvict: make(chan struct{}),
+		evictDone: make(chan struct{}),
+	}
+}
+
+func TestClusterStateAppender_ApplyPreservesIDAndTimestamp(t *testing.T) {
+	backend := &recordingBackend{}
+	a := newTestClusterStateAppender(backend)
+
+	id := uuid.Must(uuid.NewV7())
+	at := time.Now().Add(-time.Hour)
+
+	a.apply(clusterEvent{ID: id, Fingerprint: model.Fingerprint(1), At: at, State: AlertStateActive})
+
+	require.Equal(t, []uuid.UUID{id}, backend.ids)
+	require.Equal(t, []time.Time{at}, backend.ats)
+	require.Equal(t, []string{"active"}, backend.state)
+}
+
+func TestClusterStateAppender_ApplyDedupesByID(t *testing.T) {
+	backend := &recordingBackend{}
+	a := newTestClusterStateAppender(backend)
+
+	ev := clusterEvent{ID: uuid.Must(uuid.NewV7()), Fingerprint: model.Fingerprint(1), At: time.Now(), State: AlertStateActive}
+
+	a.apply(ev)
+	a.apply(ev)
+
+	require.Len(t, backend.ids, 1, "a re-delivered event must only be applied once")
+}
+
+func TestClusterStateAppender_Sync_PreservesIDAndTimestamp(t *testing.T) {
+	backend := &recordingBackend{}
+	a := newTestClusterStateAppender(backend)
+
+	id := uuid.Must(uuid.NewV7())
+	at := time.Now().Add(-24 * time.Hour)
+
+	source := &fakeClusterHistorySource{
+		transitions: []StateTransition{
+			{ID: id, Fingerprint: model.Fingerprint(1), At: at, State: AlertStateSuppressed, Inhibitors: []model.Fingerprint{2}},
+		},
+	}
+
+	require.NoError(t, a.Sync(source, 48*time.Hour))
+
+	require.Equal(t, []uuid.UUID{id}, backend.ids)
+	require.Equal(t, []time.Time{at}, backend.ats)
+	require.Equal(t, []string{"suppressed"}, backend.state)
+}
+
+type fakeClusterHistorySource struct {
+	transitions []StateTransition
+}
+
+func (f *fakeClusterHistorySource) HistoryFor(model.Fingerprint, time.Time, time.Time) ([]StateTransition, error) {
+	return nil, nil
+}
+
+func (f *fakeClusterHistorySource) ActiveAt(time.Time) (map[model.Fingerprint]AlertStatus, error) {
+	return nil, nil
+}
+
+func (f *fakeClusterHistorySource) InhibitorsOf(model.Fingerprint, time.Time) ([]model.Fingerprint, error) {
+	return nil, nil
+}
+
+func (f *fakeClusterHistorySource) AllHistorySince(time.Time) ([]StateTransition, error) {
+	return f.transitions, nil
+}
+
+func TestDedupeSet_EvictExpired(t *testing.T) {
+	s := newDedupeSet(-time.Nanosecond)
+
+	id := uuid.Must(uuid.NewV7())
+	require.True(t, s.markSeen(id))
+	require.False(t, s.markSeen(id))
+
+	s.evictExpired()
+
+	require.True(t, s.markSeen(id), "an expired entry must be forgotten so redelivery after eviction is accepted again")
+}
@@ -0,0 +1,60 @@
+package types
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// AllHistorySince implements ClusterHistorySource, returning every transition recorded at or
+// after since, across all fingerprints, ordered oldest first. It backs
+// ClusterStateAppender.Sync's bootstrap of a freshly-joined replica.
+func (r *DuckDBStateReader) AllHistorySince(since time.Time) ([]StateTransition, error) {
+	rows, err := r.db.Query(`
+		SELECT s.id, s.ts, s.fingerprint, s.state, string_agg(i.inhibitor_fingerprint, ',')
+		FROM alert_states s
+		LEFT JOIN alert_inhibitions i ON i.state_id = s.id
+		WHERE s.ts >= ?
+		GROUP BY s.id, s.ts, s.fingerprint, s.state
+		ORDER BY s.ts ASC
+	`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert state history since %s: %w", since, err)
+	}
+	defer rows.Close()
+
+	var transitions []StateTransition
+	for rows.Next() {
+		var (
+			idBinary       []byte
+			at             time.Time
+			rawFingerprint string
+			state          string
+			inhibitors     sql.NullString
+		)
+
+		if err := rows.Scan(&idBinary, &at, &rawFingerprint, &state, &inhibitors); err != nil {
+			return nil, fmt.Errorf("failed to scan alert state history row: %w", err)
+		}
+
+		fingerprint, err := model.ParseFingerprint(rawFingerprint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse fingerprint %q: %w", rawFingerprint, err)
+		}
+
+		transition, err := newStateTransition(idBinary, at, fingerprint, state, splitInhibitors(inhibitors))
+		if err != nil {
+			return nil, err
+		}
+
+		transitions = append(transitions, transition)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read alert state history since %s: %w", since, err)
+	}
+
+	return transitions, nil
+}
@@ -0,0 +1,109 @@
+package types
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// PruneOlderThan implements RetentionPruner by deleting every alert_states row (and its
+// alert_inhibitions) whose id sorts before cutoff. Since id is a UUIDv7, this is a cheap
+// `WHERE id < ?` rather than a timestamp range scan.
+func (r *DuckDBStateReader) PruneOlderThan(ctx context.Context, cutoff uuid.UUID, keepTerminal bool) (int64, error) {
+	cutoffBinary, err := cutoff.MarshalBinary()
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal retention cutoff: %w", err)
+	}
+
+	query := `DELETE FROM alert_states WHERE id < ?`
+	args := []any{cutoffBinary}
+
+	if keepTerminal {
+		query += `
+			AND NOT (
+				state = ? AND id = (
+					SELECT max(id) FROM alert_states t
+					WHERE t.fingerprint = alert_states.fingerprint AND t.state = ?
+				)
+			)`
+		args = append(args, AlertStateDeleted.String(), AlertStateDeleted.String())
+	}
+
+	deleted, err := r.execDelete(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune alert_states older than cutoff: %w", err)
+	}
+
+	if err := r.pruneOrphanedInhibitions(ctx); err != nil {
+		return deleted, err
+	}
+
+	return deleted, nil
+}
+
+// PruneRowCount implements RetentionPruner by keeping only the maxRows most recent rows (by id)
+// per fingerprint.
+func (r *DuckDBStateReader) PruneRowCount(ctx context.Context, maxRows uint64, keepTerminal bool) (int64, error) {
+	var query string
+
+	if keepTerminal {
+		query = `
+			WITH ranked AS (
+				SELECT id, fingerprint,
+					row_number() OVER (PARTITION BY fingerprint ORDER BY id DESC) AS rn,
+					max(id) FILTER (WHERE state = ?) OVER (PARTITION BY fingerprint) AS terminal_id
+				FROM alert_states
+			)
+			DELETE FROM alert_states WHERE id IN (
+				SELECT id FROM ranked WHERE rn > ? AND (terminal_id IS NULL OR id != terminal_id)
+			)`
+	} else {
+		query = `
+			WITH ranked AS (
+				SELECT id, row_number() OVER (PARTITION BY fingerprint ORDER BY id DESC) AS rn
+				FROM alert_states
+			)
+			DELETE FROM alert_states WHERE id IN (SELECT id FROM ranked WHERE rn > ?)`
+	}
+
+	var args []any
+	if keepTerminal {
+		args = append(args, AlertStateDeleted.String())
+	}
+	args = append(args, maxRows)
+
+	deleted, err := r.execDelete(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune alert_states by row count: %w", err)
+	}
+
+	if err := r.pruneOrphanedInhibitions(ctx); err != nil {
+		return deleted, err
+	}
+
+	return deleted, nil
+}
+
+func (r *DuckDBStateReader) execDelete(ctx context.Context, query string, args ...any) (int64, error) {
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// pruneOrphanedInhibitions deletes alert_inhibitions rows left behind by a pruned alert_states
+// row.
+func (r *DuckDBStateReader) pruneOrphanedInhibitions(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM alert_inhibitions
+		WHERE state_id NOT IN (SELECT id FROM alert_states)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prune orphaned alert_inhibitions rows: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RetentionPolicy configures how aggressively RetentionManager prunes alert state history.
+type RetentionPolicy struct {
+	// MaxAge drops transitions older than this. Zero disables age-based pruning.
+	MaxAge time.Duration
+	// MaxRows keeps at most this many of the most recent transitions per fingerprint. Zero
+	// disables count-based pruning.
+	MaxRows uint64
+	// KeepTerminal, when true, always retains the last AlertStateDeleted transition per
+	// fingerprint, even if it would otherwise be pruned by MaxAge or MaxRows, so that an
+	// alert's final disposition remains auditable.
+	KeepTerminal bool
+}
+
+// RetentionPruner deletes alert state rows from a storage backend. It is implemented per
+// storage engine, similarly to stateappender.Backend and StateReader.
+type RetentionPruner interface {
+	// PruneOlderThan deletes every row whose id (a UUIDv7, which sorts in creation order) is
+	// older than cutoff.
+	PruneOlderThan(ctx context.Context, cutoff uuid.UUID, keepTerminal bool) (deleted int64, err error)
+	// PruneRowCount keeps at most maxRows rows per fingerprint, deleting the oldest first.
+	PruneRowCount(ctx context.Context, maxRows uint64, keepTerminal bool) (deleted int64, err error)
+}
+
+type retentionMetrics struct {
+	rowsDeletedTotal *prometheus.CounterVec
+	duration         prometheus.Histogram
+}
+
+func newRetentionMetrics(r prometheus.Registerer) *retentionMetrics {
+	m := &retentionMetrics{
+		rowsDeletedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "alertmanager",
+			Subsystem: "state_retention",
+			Name:      "rows_deleted_total",
+			Help:      "Total number of alert state rows deleted by the retention manager.",
+		}, []string{"reason"}),
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "alertmanager",
+			Subsystem: "state_retention",
+			Name:      "duration_seconds",
+			Help:      "Time it took to run a single retention pass.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+	}
+
+	if r != nil {
+		r.MustRegister(m.rowsDeletedTotal, m.duration)
+	}
+
+	return m
+}
+
+// RetentionManager periodically prunes old alert state rows from a RetentionPruner according to
+// a RetentionPolicy, running alongside StateAwareMarker. It takes no lock and opens no
+// transaction of its own, so a pass is not isolated from a concurrent appender flush;
+// DuckDBStateReader's pruner issues each DELETE as a separate statement outside a transaction.
+type RetentionManager struct {
+	pruner   RetentionPruner
+	policy   RetentionPolicy
+	interval time.Duration
+	l        *slog.Logger
+	metrics  *retentionMetrics
+
+	cancel    context.CancelFunc
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewRetentionManager returns a RetentionManager that, once started with Start, prunes pruner
+// every interval according to policy.
+func NewRetentionManager(
+	r prometheus.Registerer,
+	pruner RetentionPruner,
+	policy RetentionPolicy,
+	interval time.Duration,
+	l *slog.Logger,
+) *RetentionManager {
+	return &RetentionManager{
+		pruner:   pruner,
+		policy:   policy,
+		interval: interval,
+		l:        l,
+		metrics:  newRetentionMetrics(r),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start runs the retention loop in its own goroutine, ticking every m.interval, until Stop is
+// called.
+func (m *RetentionManager) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	m.cancel = cancel
+
+	go m.run(ctx)
+}
+
+func (m *RetentionManager) run(ctx context.Context) {
+	defer close(m.done)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.RunOnce(ctx); err != nil {
+				m.l.Error("failed to run retention pass", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// RunOnce runs a single retention pass synchronously, pruning by age and then by row count as
+// configured in the policy. It is exposed so that tests and admin tooling can trigger a prune on
+// demand instead of waiting for the next tick.
+func (m *RetentionManager) RunOnce(ctx context.Context) error {
+	start := time.Now()
+	defer func() { m.metrics.duration.Observe(time.Since(start).Seconds()) }()
+
+	if m.policy.MaxAge > 0 {
+		cutoff := uuidV7Floor(time.Now().Add(-m.policy.MaxAge))
+
+		deleted, err := m.pruner.PruneOlderThan(ctx, cutoff, m.policy.KeepTerminal)
+		if err != nil {
+			return fmt.Errorf("failed to prune alert state rows by age: %w", err)
+		}
+
+		m.metrics.rowsDeletedTotal.WithLabelValues("age").Add(float64(deleted))
+	}
+
+	if m.policy.MaxRows > 0 {
+		deleted, err := m.pruner.PruneRowCount(ctx, m.policy.MaxRows, m.policy.KeepTerminal)
+		if err != nil {
+			return fmt.Errorf("failed to prune alert state rows by count: %w", err)
+		}
+
+		m.metrics.rowsDeletedTotal.WithLabelValues("count").Add(float64(deleted))
+	}
+
+	return nil
+}
+
+// Stop cancels the retention loop started by Start and waits for it to exit. It is a no-op if
+// Start was never called.
+func (m *RetentionManager) Stop() {
+	m.closeOnce.Do(func() {
+		if m.cancel == nil {
+			return
+		}
+
+		m.cancel()
+		<-m.done
+	})
+}
+
+// uuidV7Floor returns the smallest possible UUIDv7 for the millisecond in which t falls, i.e.
+// the 48-bit big-endian timestamp prefix with every other bit zeroed (version/variant bits
+// aside). Since UUIDv7's timestamp prefix sorts lexically in creation order, this is a cheap
+// `id < cutoff` boundary: any row with a strictly earlier id was created in an earlier
+// millisecond than t.
+func uuidV7Floor(t time.Time) uuid.UUID {
+	ms := uint64(t.UnixMilli())
+
+	var u uuid.UUID
+	u[0] = byte(ms >> 40)
+	u[1] = byte(ms >> 32)
+	u[2] = byte(ms >> 24)
+	u[3] = byte(ms >> 16)
+	u[4] = byte(ms >> 8)
+	u[5] = byte(ms)
+	u[6] = 0x70 // version 7, zeroed rand_a
+	u[8] = 0x80 // variant 10, zeroed rand_b
+
+	return u
+}
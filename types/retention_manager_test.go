@@ -0,0 +1,55 @@
+package types
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRetentionPruner struct {
+	olderThanCalls int
+	rowCountCalls  int
+}
+
+func (f *fakeRetentionPruner) PruneOlderThan(context.Context, uuid.UUID, bool) (int64, error) {
+	f.olderThanCalls++
+	return 3, nil
+}
+
+func (f *fakeRetentionPruner) PruneRowCount(context.Context, uint64, bool) (int64, error) {
+	f.rowCountCalls++
+	return 2, nil
+}
+
+func TestRetentionManager_RunOnce(t *testing.T) {
+	pruner := &fakeRetentionPruner{}
+	m := NewRetentionManager(prometheus.NewRegistry(), pruner, RetentionPolicy{
+		MaxAge:  time.Hour,
+		MaxRows: 100,
+	}, time.Minute, nil)
+
+	require.NoError(t, m.RunOnce(context.Background()))
+	require.Equal(t, 1, pruner.olderThanCalls)
+	require.Equal(t, 1, pruner.rowCountCalls)
+}
+
+func TestRetentionManager_RunOnce_DisabledPolicy(t *testing.T) {
+	pruner := &fakeRetentionPruner{}
+	m := NewRetentionManager(prometheus.NewRegistry(), pruner, RetentionPolicy{}, time.Minute, nil)
+
+	require.NoError(t, m.RunOnce(context.Background()))
+	require.Equal(t, 0, pruner.olderThanCalls)
+	require.Equal(t, 0, pruner.rowCountCalls)
+}
+
+func TestUUIDV7Floor_Monotonic(t *testing.T) {
+	now := time.Now()
+	earlier := uuidV7Floor(now.Add(-time.Hour))
+	later := uuidV7Floor(now)
+
+	require.Less(t, earlier.String(), later.String())
+}
@@ -0,0 +1,307 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+
+	"github.com/prometheus/alertmanager/stateappender"
+)
+
+// RetryPolicy decides how long to wait before the next retry attempt, and whether retrying
+// should stop altogether. It exists so that callers can plug in their own strategy, e.g. a
+// deterministic one for unit tests.
+type RetryPolicy interface {
+	// NextBackOff returns the delay to wait before the given attempt (1-indexed). ok is false
+	// once the policy has given up, e.g. because MaxRetries has been reached.
+	NextBackOff(attempt int) (delay time.Duration, ok bool)
+}
+
+// BackoffConfig configures the default, exponential-with-jitter RetryPolicy.
+type BackoffConfig struct {
+	// MinBackoff is the base delay for the first retry attempt; full jitter then picks the
+	// actual delay in [0, MinBackoff].
+	MinBackoff time.Duration
+	// MaxBackoff caps the delay between retries.
+	MaxBackoff time.Duration
+	// Multiplier is applied to the previous delay to compute the next one, before jitter and
+	// the MaxBackoff cap are applied.
+	Multiplier float64
+	// MaxRetries is the number of retries made after the first failed attempt before a row is
+	// given up on. Zero means retry forever.
+	MaxRetries int
+}
+
+// DefaultBackoffConfig returns sane defaults for BackoffConfig.
+func DefaultBackoffConfig() BackoffConfig {
+	return BackoffConfig{
+		MinBackoff: 500 * time.Millisecond,
+		MaxBackoff: time.Minute,
+		Multiplier: 2,
+		MaxRetries: 10,
+	}
+}
+
+// exponentialBackoffPolicy is the default RetryPolicy: exponential backoff with full jitter.
+type exponentialBackoffPolicy struct {
+	cfg BackoffConfig
+}
+
+// NewExponentialBackoffPolicy returns a RetryPolicy that grows the delay between retries
+// exponentially, up to cfg.MaxBackoff, with jitter applied to avoid thundering-herd retries.
+func NewExponentialBackoffPolicy(cfg BackoffConfig) RetryPolicy {
+	return &exponentialBackoffPolicy{cfg: cfg}
+}
+
+func (p *exponentialBackoffPolicy) NextBackOff(attempt int) (time.Duration, bool) {
+	if p.cfg.MaxRetries > 0 && attempt > p.cfg.MaxRetries {
+		return 0, false
+	}
+
+	delay := float64(p.cfg.MinBackoff) * math.Pow(p.cfg.Multiplier, float64(attempt-1))
+	if max := float64(p.cfg.MaxBackoff); delay > max {
+		delay = max
+	}
+
+	// Full jitter: pick a random delay in [0, delay].
+	jittered := time.Duration(rand.Float64() * delay)
+
+	return jittered, true
+}
+
+// retryReason labels why a row was permanently dropped.
+type retryReason string
+
+const (
+	reasonContextCanceled         retryReason = "context_canceled"
+	reasonContextDeadlineExceeded retryReason = "context_deadline_exceeded"
+	reasonMaxRetriesExceeded      retryReason = "max_retries_exceeded"
+)
+
+// retryingAppenderMetrics holds the prometheus instrumentation exposed by RetryingStateAppender.
+type retryingAppenderMetrics struct {
+	permanentlyDroppedTotal *prometheus.CounterVec
+}
+
+func newRetryingAppenderMetrics(r prometheus.Registerer) *retryingAppenderMetrics {
+	m := &retryingAppenderMetrics{
+		permanentlyDroppedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "alertmanager",
+			Subsystem: "state_appender",
+			Name:      "permanently_dropped_total",
+			Help:      "Total number of state records permanently dropped after exhausting retries.",
+		}, []string{"reason"}),
+	}
+
+	if r != nil {
+		r.MustRegister(m.permanentlyDroppedTotal)
+	}
+
+	return m
+}
+
+// retryableRow is a single failed backend write held in memory while it is retried in the
+// background. id and at are fixed at the first attempt and carried through every retry, so a row
+// that eventually succeeds is recorded under the same primary key and timestamp regardless of
+// how many attempts it took.
+type retryableRow struct {
+	id          uuid.UUID
+	at          time.Time
+	fingerprint model.Fingerprint
+	state       AlertState
+	inhibited   bool
+	inhibitedBy []string
+}
+
+func (row retryableRow) write(backend stateappender.Backend) error {
+	if row.inhibited {
+		return backend.InsertInhibited(row.id, row.fingerprint, row.at, row.inhibitedBy)
+	}
+
+	return insertState(backend, row.id, row.fingerprint, row.state, row.at)
+}
+
+// RetryingStateAppender wraps a stateappender.Backend and retries failed writes in the
+// background, using an exponential backoff with jitter by default. It operates on a Backend
+// rather than a StateAppender specifically so it can see whether a write actually failed before
+// scheduling a retry, instead of unconditionally retrying every call.
+type RetryingStateAppender struct {
+	backend stateappender.Backend
+	policy  RetryPolicy
+	l       *slog.Logger
+
+	metrics *retryingAppenderMetrics
+
+	ctx       context.Context
+	cancel    context.CancelCauseFunc
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+
+	// droppedOnShutdown is set when in-flight retries are cut short by Close, as opposed to
+	// giving up on their own after exhausting the retry policy.
+	droppedOnShutdown atomic.Bool
+	// permanentlyDropped counts rows that exhausted the retry policy on a backend that never
+	// recovered, independent of whether the appender was ever closed.
+	permanentlyDropped atomic.Int64
+}
+
+// NewRetryingStateAppender returns a RetryingStateAppender wrapping backend. policy controls the
+// retry behaviour; pass NewExponentialBackoffPolicy(DefaultBackoffConfig()) for the default
+// behaviour.
+func NewRetryingStateAppender(
+	r prometheus.Registerer,
+	backend stateappender.Backend,
+	policy RetryPolicy,
+	l *slog.Logger,
+) *RetryingStateAppender {
+	ctx, cancel := context.WithCancelCause(context.Background())
+
+	return &RetryingStateAppender{
+		backend: backend,
+		policy:  policy,
+		l:       l,
+		metrics: newRetryingAppenderMetrics(r),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+func (a *RetryingStateAppender) Append(fingerprint model.Fingerprint, state AlertState) {
+	row := retryableRow{id: uuid.Must(uuid.NewV7()), at: time.Now(), fingerprint: fingerprint, state: state}
+
+	if err := row.write(a.backend); err != nil {
+		a.l.Warn("failed to append alert state, will retry", slog.Any("error", err))
+		a.retry(row)
+	}
+}
+
+func (a *RetryingStateAppender) AppendInhibited(fingerprint model.Fingerprint, inhibitedBy []string) {
+	row := retryableRow{
+		id:          uuid.Must(uuid.NewV7()),
+		at:          time.Now(),
+		fingerprint: fingerprint,
+		inhibited:   true,
+		inhibitedBy: inhibitedBy,
+	}
+
+	if err := row.write(a.backend); err != nil {
+		a.l.Warn("failed to append inhibited alert state, will retry", slog.Any("error", err))
+		a.retry(row)
+	}
+}
+
+// retry starts a background goroutine that re-applies row until it succeeds, the retry policy
+// gives up, or the appender is shut down.
+func (a *RetryingStateAppender) retry(row retryableRow) {
+	a.wg.Add(1)
+
+	go func() {
+		defer a.wg.Done()
+
+		attempt := 0
+		for {
+			attempt++
+
+			delay, ok := a.policy.NextBackOff(attempt)
+			if !ok {
+				a.giveUp(row, reasonMaxRetriesExceeded)
+				return
+			}
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-a.ctx.Done():
+				timer.Stop()
+				a.giveUp(row, a.shutdownReason())
+				return
+			}
+
+			a.l.Warn("retrying failed alert state append",
+				slog.Int("attempt", attempt),
+				slog.String("fingerprint", row.fingerprint.String()),
+			)
+
+			if err := row.write(a.backend); err != nil {
+				a.l.Warn("retry failed alert state append again",
+					slog.Int("attempt", attempt),
+					slog.Any("error", err),
+				)
+				continue
+			}
+
+			return
+		}
+	}()
+}
+
+func (a *RetryingStateAppender) shutdownReason() retryReason {
+	cause := context.Cause(a.ctx)
+	if errors.Is(cause, context.DeadlineExceeded) {
+		return reasonContextDeadlineExceeded
+	}
+
+	return reasonContextCanceled
+}
+
+func (a *RetryingStateAppender) giveUp(row retryableRow, reason retryReason) {
+	a.metrics.permanentlyDroppedTotal.WithLabelValues(string(reason)).Inc()
+
+	switch reason {
+	case reasonMaxRetriesExceeded:
+		a.permanentlyDropped.Add(1)
+	default:
+		a.droppedOnShutdown.Store(true)
+	}
+
+	a.l.Warn("giving up on alert state append",
+		slog.String("fingerprint", row.fingerprint.String()),
+		slog.String("reason", string(reason)),
+	)
+}
+
+func (a *RetryingStateAppender) Flush() error {
+	return a.backend.Flush()
+}
+
+// Close cancels any in-flight retries, waits for them to unwind, and closes the wrapped Backend.
+// The returned error reports every row lost along the way, whether to shutdown or to a backend
+// that never recovered within the retry policy, so operators can tell the two apart. It is safe
+// to call Close more than once.
+func (a *RetryingStateAppender) Close() error {
+	var err error
+
+	a.closeOnce.Do(func() {
+		a.cancel(fmt.Errorf("retrying state appender closed"))
+		a.wg.Wait()
+
+		closeErr := a.backend.Close()
+
+		var errs []error
+		if closeErr != nil {
+			errs = append(errs, fmt.Errorf("failed to close wrapped state backend: %w", closeErr))
+		}
+
+		if dropped := a.permanentlyDropped.Load(); dropped > 0 {
+			errs = append(errs, fmt.Errorf("%d alert state row(s) permanently dropped after exhausting retries", dropped))
+		}
+
+		if a.droppedOnShutdown.Load() {
+			errs = append(errs, fmt.Errorf("lost in-flight alert state rows on shutdown: %w", context.Cause(a.ctx)))
+		}
+
+		err = errors.Join(errs...)
+	})
+
+	return err
+}
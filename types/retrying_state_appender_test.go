@@ -0,0 +1,143 @@
+package types
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/alertmanager/stateappender"
+)
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// fakeBackend implements stateappender.Backend and fails the first failUntil inserts before
+// succeeding, so tests can exercise the retry path deterministically.
+type fakeBackend struct {
+	failUntil int
+
+	calls int
+	ids   []uuid.UUID
+}
+
+func (f *fakeBackend) insert(id uuid.UUID) error {
+	f.calls++
+	f.ids = append(f.ids, id)
+
+	if f.calls <= f.failUntil {
+		return errAppendFailed
+	}
+
+	return nil
+}
+
+var errAppendFailed = fakeAppendError{}
+
+type fakeAppendError struct{}
+
+func (fakeAppendError) Error() string { return "fake append failure" }
+
+func (f *fakeBackend) InsertActive(id uuid.UUID, _ model.Fingerprint, _ time.Time) error {
+	return f.insert(id)
+}
+
+func (f *fakeBackend) InsertSuppressed(id uuid.UUID, _ model.Fingerprint, _ time.Time) error {
+	return f.insert(id)
+}
+
+func (f *fakeBackend) InsertInhibited(id uuid.UUID, _ model.Fingerprint, _ time.Time, _ []string) error {
+	return f.insert(id)
+}
+
+func (f *fakeBackend) InsertDeleted(id uuid.UUID, _ model.Fingerprint, _ time.Time) error {
+	return f.insert(id)
+}
+
+func (f *fakeBackend) Flush() error { return nil }
+
+func (f *fakeBackend) Close() error { return nil }
+
+var _ stateappender.Backend = &fakeBackend{}
+
+// blockingRetryPolicy never gives up and always waits out a long delay, so the only way a retry
+// loop using it ever stops is the appender being closed out from under it.
+type blockingRetryPolicy struct{}
+
+func (blockingRetryPolicy) NextBackOff(int) (time.Duration, bool) {
+	return time.Hour, true
+}
+
+// immediateRetryPolicy retries up to maxRetries times with no delay, so tests don't have to wait
+// out real backoff durations.
+type immediateRetryPolicy struct {
+	maxRetries int
+}
+
+func (p immediateRetryPolicy) NextBackOff(attempt int) (time.Duration, bool) {
+	if attempt > p.maxRetries {
+		return 0, false
+	}
+
+	return 0, true
+}
+
+func TestRetryingStateAppender_SucceedsWithoutRetry(t *testing.T) {
+	backend := &fakeBackend{}
+	a := NewRetryingStateAppender(prometheus.NewRegistry(), backend, immediateRetryPolicy{maxRetries: 5}, discardLogger())
+	defer a.Close()
+
+	a.Append(model.Fingerprint(1), AlertStateActive)
+	require.NoError(t, a.Close())
+
+	require.Equal(t, 1, backend.calls, "a successful write must not be retried")
+}
+
+func TestRetryingStateAppender_RetriesUntilSuccessWithSameID(t *testing.T) {
+	backend := &fakeBackend{failUntil: 2}
+	a := NewRetryingStateAppender(prometheus.NewRegistry(), backend, immediateRetryPolicy{maxRetries: 5}, discardLogger())
+
+	a.Append(model.Fingerprint(1), AlertStateActive)
+	// Let the retries run to completion; Close would otherwise cancel them.
+	a.wg.Wait()
+	require.NoError(t, a.Close())
+
+	require.Equal(t, 3, backend.calls)
+	for _, id := range backend.ids {
+		require.Equal(t, backend.ids[0], id, "every retry must reuse the id from the first attempt")
+	}
+}
+
+func TestRetryingStateAppender_GivesUpAfterMaxRetries(t *testing.T) {
+	backend := &fakeBackend{failUntil: 100}
+	a := NewRetryingStateAppender(prometheus.NewRegistry(), backend, immediateRetryPolicy{maxRetries: 2}, discardLogger())
+
+	a.Append(model.Fingerprint(1), AlertStateActive)
+	// Let the retries run out on their own; Close would otherwise cancel them.
+	a.wg.Wait()
+	err := a.Close()
+
+	require.Equal(t, 3, backend.calls, "first attempt plus 2 retries")
+	require.False(t, a.droppedOnShutdown.Load(), "giving up after exhausting retries is not a shutdown drop")
+	require.ErrorContains(t, err, "permanently dropped after exhausting retries",
+		"a row lost to a real backend outage must surface through Close's error")
+}
+
+func TestRetryingStateAppender_Close_ReportsInFlightDropOnShutdown(t *testing.T) {
+	backend := &fakeBackend{failUntil: 100}
+	// blockingRetryPolicy never gives up on its own, so the only way this row is ever dropped is
+	// Close cutting it short.
+	a := NewRetryingStateAppender(prometheus.NewRegistry(), backend, blockingRetryPolicy{}, discardLogger())
+
+	a.Append(model.Fingerprint(1), AlertStateActive)
+	err := a.Close()
+
+	require.True(t, a.droppedOnShutdown.Load())
+	require.ErrorContains(t, err, "lost in-flight alert state rows on shutdown")
+}
@@ -3,7 +3,6 @@
 package types
 
 import (
-	"context"
 	"database/sql/driver"
 	"fmt"
 	"log/slog"
@@ -14,8 +13,8 @@ import (
 	"github.com/prometheus/common/model"
 
 	"github.com/google/uuid"
-	"github.com/marcboeker/go-duckdb"
-	_ "github.com/marcboeker/go-duckdb"
+
+	"github.com/prometheus/alertmanager/stateappender"
 )
 
 type marker interface {
@@ -49,87 +48,78 @@ type StateAppender interface {
 	Flush() error
 }
 
+// NewDuckDBStateAppender returns a StateAppender that writes alert state transitions to DuckDB
+// over conn. It is a thin convenience wrapper around stateappender.NewDuckDBBackend for callers
+// that already manage their own DuckDB connection; callers that want a different storage engine
+// (or to resolve one from a DSN) should use stateappender.DefaultRegistry.Open directly.
 func NewDuckDBStateAppender(
-	ctx context.Context,
 	conn driver.Conn,
 	l *slog.Logger,
-) (*DuckDBStateAppender, error) {
-	appender, err := duckdb.NewAppenderFromConn(conn, "main", "alert_states")
+) (*BackendStateAppender, error) {
+	backend, err := stateappender.NewDuckDBBackend(conn, l)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create new appender: %w", err)
+		return nil, fmt.Errorf("failed to create duckdb state backend: %w", err)
 	}
 
-	return &DuckDBStateAppender{appender, l}, nil
+	return NewBackendStateAppender(backend, l), nil
 }
 
-// DuckDBStateAppender implements StateAppender
-type DuckDBStateAppender struct {
-	db *duckdb.Appender
-	l  *slog.Logger
+// NewBackendStateAppender returns a StateAppender that persists state transitions through
+// backend, translating each AlertState into the matching Backend method.
+func NewBackendStateAppender(backend stateappender.Backend, l *slog.Logger) *BackendStateAppender {
+	return &BackendStateAppender{backend: backend, l: l}
 }
 
-func (d *DuckDBStateAppender) Close() error {
-	return d.db.Close()
+// BackendStateAppender implements StateAppender on top of a stateappender.Backend, so that the
+// choice of storage engine (DuckDB, SQLite, Postgres, ...) is independent of StateAwareMarker.
+type BackendStateAppender struct {
+	backend stateappender.Backend
+	l       *slog.Logger
 }
 
-func (d *DuckDBStateAppender) Flush() error {
-	if err := d.db.Flush(); err != nil {
-		return fmt.Errorf("failed to flush duckdb state appender: %w", err)
-	}
-	return nil
+func (b *BackendStateAppender) Close() error {
+	return b.backend.Close()
 }
 
-func (d *DuckDBStateAppender) Append(fingerprint model.Fingerprint, state AlertState) {
-	id := uuid.Must(uuid.NewV7())
-	var err error
+func (b *BackendStateAppender) Flush() error {
+	return b.backend.Flush()
+}
 
-	defer func() {
-		if err != nil {
-			d.l.Error("failed to append alert state in db", slog.Any("error", err))
-		}
-	}()
+func (b *BackendStateAppender) Append(fingerprint model.Fingerprint, state AlertState) {
+	id := uuid.Must(uuid.NewV7())
 
-	idBinary, err := id.MarshalBinary()
-	if err != nil {
-		return
+	if err := insertState(b.backend, id, fingerprint, state, time.Now()); err != nil {
+		b.l.Error("failed to append alert state", slog.Any("error", err))
 	}
+}
 
-	if state == AlertStateSuppressed {
-		err = d.db.AppendRow(
-			idBinary,
-			time.Now(),
-			fingerprint.String(),
-			state.String(),
-			nil,
-			"silenced",
-		)
-
-		return
+// insertState routes state to the Backend method that matches it. It handles AlertStateActive,
+// AlertStateSuppressed and AlertStateDeleted, and returns an error for any other state
+// (including AlertStateUnprocessed) rather than silently falling back to InsertActive.
+func insertState(
+	backend stateappender.Backend,
+	id uuid.UUID,
+	fingerprint model.Fingerprint,
+	state AlertState,
+	at time.Time,
+) error {
+	switch state {
+	case AlertStateActive:
+		return backend.InsertActive(id, fingerprint, at)
+	case AlertStateSuppressed:
+		return backend.InsertSuppressed(id, fingerprint, at)
+	case AlertStateDeleted:
+		return backend.InsertDeleted(id, fingerprint, at)
+	default:
+		return fmt.Errorf("no backend insert method for alert state %q", state)
 	}
-
-	err = d.db.AppendRow(idBinary, time.Now(), fingerprint.String(), state.String(), nil, nil)
 }
 
-func (d *DuckDBStateAppender) AppendInhibited(fingerprint model.Fingerprint, inhibitedBy []string) {
-	// TODO(khellemun): change table to many to many
+func (b *BackendStateAppender) AppendInhibited(fingerprint model.Fingerprint, inhibitedBy []string) {
 	id := uuid.Must(uuid.NewV7())
 
-	idBinary, err := id.MarshalBinary()
-	if err != nil {
-		return
-	}
-
-	err = d.db.AppendRow(
-		idBinary,
-		time.Now(),
-		fingerprint.String(),
-		AlertStateSuppressed.String(),
-		inhibitedBy[0],
-		"inhibited",
-	)
-
-	if err != nil {
-		d.l.Error("failed to append inhibited alert state in db", slog.Any("error", err))
+	if err := b.backend.InsertInhibited(id, fingerprint, time.Now(), inhibitedBy); err != nil {
+		b.l.Error("failed to append inhibited alert state", slog.Any("error", err))
 	}
 }
 
@@ -0,0 +1,256 @@
+package types
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/common/model"
+
+	_ "github.com/marcboeker/go-duckdb"
+)
+
+// StateTransition is a single recorded change of an alert's state, as returned by StateReader.
+type StateTransition struct {
+	ID          uuid.UUID
+	At          time.Time
+	Fingerprint model.Fingerprint
+	State       AlertState
+	// Inhibitors holds the fingerprints of the alerts that caused this transition, when State
+	// is AlertStateSuppressed because of inhibition. It is empty otherwise.
+	Inhibitors []model.Fingerprint
+}
+
+// StateReader reads back the alert state history recorded by a StateAppender, so that operators
+// can answer questions like "why was this alert suppressed at 03:12?" after the fact.
+type StateReader interface {
+	// HistoryFor returns the state transitions recorded for fingerprint in [from, to), ordered
+	// oldest first.
+	HistoryFor(fingerprint model.Fingerprint, from, to time.Time) ([]StateTransition, error)
+	// ActiveAt returns the status of every alert whose most recent transition at or before t
+	// was to AlertStateActive, keyed by fingerprint.
+	ActiveAt(t time.Time) (map[model.Fingerprint]AlertStatus, error)
+	// InhibitorsOf returns the fingerprints of the alerts that were inhibiting fingerprint at
+	// time t, based on the most recent transition at or before t.
+	InhibitorsOf(fingerprint model.Fingerprint, at time.Time) ([]model.Fingerprint, error)
+}
+
+// NewDuckDBStateReader returns a StateReader that queries alert state history from the DuckDB
+// database at dsn.
+func NewDuckDBStateReader(dsn string) (*DuckDBStateReader, error) {
+	db, err := sql.Open("duckdb", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open duckdb database: %w", err)
+	}
+
+	return &DuckDBStateReader{db: db}, nil
+}
+
+// DuckDBStateReader implements StateReader on top of the "alert_states" and "alert_inhibitions"
+// tables written by DuckDBBackend.
+type DuckDBStateReader struct {
+	db *sql.DB
+}
+
+func (r *DuckDBStateReader) Close() error {
+	if err := r.db.Close(); err != nil {
+		return fmt.Errorf("failed to close duckdb state reader: %w", err)
+	}
+
+	return nil
+}
+
+func (r *DuckDBStateReader) HistoryFor(
+	fingerprint model.Fingerprint,
+	from, to time.Time,
+) ([]StateTransition, error) {
+	rows, err := r.db.Query(`
+		SELECT s.id, s.ts, s.state, string_agg(i.inhibitor_fingerprint, ',')
+		FROM alert_states s
+		LEFT JOIN alert_inhibitions i ON i.state_id = s.id
+		WHERE s.fingerprint = ? AND s.ts >= ? AND s.ts < ?
+		GROUP BY s.id, s.ts, s.state
+		ORDER BY s.ts ASC
+	`, fingerprint.String(), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert state history: %w", err)
+	}
+	defer rows.Close()
+
+	var transitions []StateTransition
+	for rows.Next() {
+		var (
+			idBinary   []byte
+			at         time.Time
+			state      string
+			inhibitors sql.NullString
+		)
+
+		if err := rows.Scan(&idBinary, &at, &state, &inhibitors); err != nil {
+			return nil, fmt.Errorf("failed to scan alert state history row: %w", err)
+		}
+
+		transition, err := newStateTransition(idBinary, at, fingerprint, state, splitInhibitors(inhibitors))
+		if err != nil {
+			return nil, err
+		}
+
+		transitions = append(transitions, transition)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read alert state history: %w", err)
+	}
+
+	return transitions, nil
+}
+
+func (r *DuckDBStateReader) ActiveAt(t time.Time) (map[model.Fingerprint]AlertStatus, error) {
+	rows, err := r.db.Query(`
+		WITH latest AS (
+			SELECT fingerprint, state,
+				row_number() OVER (PARTITION BY fingerprint ORDER BY ts DESC) AS rn
+			FROM alert_states
+			WHERE ts <= ?
+		)
+		SELECT fingerprint FROM latest WHERE rn = 1 AND state = ?
+	`, t, AlertStateActive.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active alerts: %w", err)
+	}
+	defer rows.Close()
+
+	statuses := make(map[model.Fingerprint]AlertStatus)
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, fmt.Errorf("failed to scan active alert row: %w", err)
+		}
+
+		fingerprint, err := model.ParseFingerprint(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse fingerprint %q: %w", raw, err)
+		}
+
+		statuses[fingerprint] = AlertStatus{State: AlertStateActive}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read active alerts: %w", err)
+	}
+
+	return statuses, nil
+}
+
+func (r *DuckDBStateReader) InhibitorsOf(fingerprint model.Fingerprint, at time.Time) ([]model.Fingerprint, error) {
+	row := r.db.QueryRow(`
+		SELECT s.id
+		FROM alert_states s
+		WHERE s.fingerprint = ? AND s.ts <= ?
+		ORDER BY s.ts DESC
+		LIMIT 1
+	`, fingerprint.String(), at)
+
+	var idBinary []byte
+	if err := row.Scan(&idBinary); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to find latest state for %s: %w", fingerprint, err)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT inhibitor_fingerprint FROM alert_inhibitions WHERE state_id = ?
+	`, idBinary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query inhibitors of %s: %w", fingerprint, err)
+	}
+	defer rows.Close()
+
+	var inhibitors []model.Fingerprint
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, fmt.Errorf("failed to scan inhibitor row: %w", err)
+		}
+
+		fp, err := model.ParseFingerprint(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse inhibitor fingerprint %q: %w", raw, err)
+		}
+
+		inhibitors = append(inhibitors, fp)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read inhibitors of %s: %w", fingerprint, err)
+	}
+
+	return inhibitors, nil
+}
+
+// parseAlertState maps the string stored in the "state" column back to an AlertState, matching
+// whichever AlertState.String() produced it.
+func parseAlertState(s string) AlertState {
+	for _, state := range []AlertState{
+		AlertStateUnprocessed,
+		AlertStateActive,
+		AlertStateSuppressed,
+		AlertStateDeleted,
+	} {
+		if state.String() == s {
+			return state
+		}
+	}
+
+	return AlertStateUnprocessed
+}
+
+// splitInhibitors turns the comma-joined result of a string_agg(inhibitor_fingerprint, ',')
+// aggregate back into individual fingerprint strings. go-duckdb can't Scan its list() aggregate
+// (a driver.Value of type []interface{}) into a Go slice, so queries use string_agg instead.
+func splitInhibitors(agg sql.NullString) []string {
+	if !agg.Valid || agg.String == "" {
+		return nil
+	}
+
+	return strings.Split(agg.String, ",")
+}
+
+func newStateTransition(
+	idBinary []byte,
+	at time.Time,
+	fingerprint model.Fingerprint,
+	state string,
+	inhibitors []string,
+) (StateTransition, error) {
+	var id uuid.UUID
+	if err := id.UnmarshalBinary(idBinary); err != nil {
+		return StateTransition{}, fmt.Errorf("failed to unmarshal state id: %w", err)
+	}
+
+	transition := StateTransition{
+		ID:          id,
+		At:          at,
+		Fingerprint: fingerprint,
+		State:       parseAlertState(state),
+	}
+
+	for _, raw := range inhibitors {
+		if raw == "" {
+			continue
+		}
+
+		fp, err := model.ParseFingerprint(raw)
+		if err != nil {
+			return StateTransition{}, fmt.Errorf("failed to parse inhibitor fingerprint %q: %w", raw, err)
+		}
+
+		transition.Inhibitors = append(transition.Inhibitors, fp)
+	}
+
+	return transition, nil
+}